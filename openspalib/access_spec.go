@@ -0,0 +1,146 @@
+package openspalib
+
+import (
+	"github.com/pkg/errors"
+	"net"
+)
+
+// maxAccessSpecs is the most AccessSpec entries a single PDU may carry. RequestBody.Marshal
+// also rejects a body whose misc field, replay token and access specs together don't fit
+// inside BodyMaxSize; maxAccessSpecs entries take maxAccessSpecs*accessSpecSize (352) bytes.
+const maxAccessSpecs = 16
+
+// accessSpecSize is the wire size, in bytes, of a single encoded AccessSpec: 1 byte protocol,
+// 2 bytes start port, 2 bytes end port, 16 bytes destination prefix (an IPv6 address, or an
+// IPv4 address as an IPv4-mapped IPv6 address, per ipAddressToBinIP), 1 byte prefix length.
+const accessSpecSize = 1 + 2 + 2 + 16 + 1 // bytes
+
+var (
+	ErrAccessSpecTooMany = errors.New("too many access specs")
+	ErrPrefixLenInvalid  = errors.New("prefix length is invalid")
+)
+
+// AccessSpec describes a single access rule requested by the client: the protocol, a port
+// range on that protocol, and the destination address range (CIDR) the rule applies to. It
+// generalizes the single destination-port model to also cover port ranges spanning multiple
+// protocols at once and site-to-site / split-tunnel CIDR requests, while reusing the same
+// start/end port validation the single-port path already has.
+type AccessSpec struct {
+	Protocol     InternetProtocolNumber
+	StartPort    uint16
+	EndPort      uint16
+	DstPrefix    net.IP
+	DstPrefixLen uint8
+}
+
+// validate checks that the spec is internally consistent, reusing the same port validation
+// rules as the single destination-port path.
+func (s AccessSpec) validate() error {
+	if _, err := decodePort(encodePort(s.StartPort), s.Protocol); err != nil {
+		return ErrUnsupportedStartPort
+	}
+
+	if _, err := decodePort(encodePort(s.EndPort), s.Protocol); err != nil {
+		return ErrUnsupportedEndPort
+	}
+
+	if s.EndPort < s.StartPort {
+		return ErrStartEndPortMismatch
+	}
+
+	maxPrefixLen := uint8(32)
+	if ipIs6, _ := isIPv6(s.DstPrefix.String()); ipIs6 {
+		maxPrefixLen = 128
+	}
+
+	if s.DstPrefixLen > maxPrefixLen {
+		return ErrPrefixLenInvalid
+	}
+
+	return nil
+}
+
+// encodeAccessSpecs encodes a list of AccessSpec entries into the access spec TLV appended
+// after the misc field. The caller is expected to have already checked the result still fits
+// within BodyMaxSize alongside the rest of the body.
+func encodeAccessSpecs(specs []AccessSpec) ([]byte, error) {
+	if len(specs) > maxAccessSpecs {
+		return nil, ErrAccessSpecTooMany
+	}
+
+	buff := make([]byte, 0, len(specs)*accessSpecSize)
+
+	for _, spec := range specs {
+		if err := spec.validate(); err != nil {
+			return nil, err
+		}
+
+		prefixBin, err := ipAddressToBinIP(spec.DstPrefix)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to encode access spec destination prefix")
+		}
+
+		entry := make([]byte, accessSpecSize)
+		entry[0] = byte(spec.Protocol)
+		encodePortTo(entry[1:3], spec.StartPort)
+		encodePortTo(entry[3:5], spec.EndPort)
+		copy(entry[5:21], prefixBin)
+		entry[21] = spec.DstPrefixLen
+
+		buff = append(buff, entry...)
+	}
+
+	return buff, nil
+}
+
+// decodeAccessSpecs decodes the access spec TLV appended after the misc field back into a
+// list of AccessSpec entries.
+func decodeAccessSpecs(data []byte) ([]AccessSpec, error) {
+	if len(data)%accessSpecSize != 0 {
+		return nil, errors.New("access spec data is not a multiple of the access spec size")
+	}
+
+	count := len(data) / accessSpecSize
+	if count > maxAccessSpecs {
+		return nil, ErrAccessSpecTooMany
+	}
+
+	specs := make([]AccessSpec, 0, count)
+
+	for i := 0; i < count; i++ {
+		entry := data[i*accessSpecSize : (i+1)*accessSpecSize]
+
+		protocol := InternetProtocolNumber(entry[0])
+
+		startPort, err := decodePort(entry[1:3], protocol)
+		if err != nil {
+			return nil, err
+		}
+
+		endPort, err := decodePort(entry[3:5], protocol)
+		if err != nil {
+			return nil, err
+		}
+
+		prefix, err := binIPAddressToIP(entry[5:21])
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decode access spec destination prefix")
+		}
+
+		spec := AccessSpec{
+			Protocol:     protocol,
+			StartPort:    startPort,
+			EndPort:      endPort,
+			DstPrefix:    prefix,
+			DstPrefixLen: entry[21],
+		}
+
+		if err := spec.validate(); err != nil {
+			return nil, err
+		}
+
+		specs = append(specs, spec)
+	}
+
+	return specs, nil
+}
@@ -0,0 +1,64 @@
+package openspalib
+
+import (
+	"github.com/pkg/errors"
+	"net"
+)
+
+// defaultPDUMaxSize is the PDU size advertised by transports that don't have a tighter
+// limit of their own (e.g. a connected UDP socket on a typical Ethernet MTU).
+const defaultPDUMaxSize = 1408 // bytes (transport payload i.e. OpenSPA header + body)
+
+var (
+	ErrEndpointTypeMismatch = errors.New("endpoint is not of the type expected by this bind")
+	ErrBindClosed           = errors.New("bind is closed")
+)
+
+// Endpoint represents the other side of an OpenSPA exchange - typically the client's public
+// address as seen by the server, or the server's configured address as seen by the client.
+// Implementations are transport-specific, mirroring the approach used by wireguard-go's
+// conn.Endpoint, so that a Bind never has to leak its internal addressing details upwards.
+type Endpoint interface {
+	// SrcIP is the source address the packet that created this Endpoint arrived from.
+	SrcIP() net.IP
+
+	// DstIP is the address this Endpoint should be sent to.
+	DstIP() net.IP
+
+	// DstToBytes returns a transport-independent byte representation of the destination,
+	// suitable for mixing into anti-replay or cookie (mac2-style) calculations.
+	DstToBytes() []byte
+
+	// ClearSrc clears any cached source address, forcing it to be re-learned from the next
+	// packet received on this Endpoint. Used when roaming clients change address.
+	ClearSrc()
+}
+
+// Bind is a transport binding capable of sending and receiving OpenSPA PDUs. Modeled on
+// wireguard-go's Bind interface so that openspalib isn't hard-wired to UDP: a Bind
+// implementation may be connected UDP, an ICMP-tunneled transport, or a TCP fallback for
+// networks that filter everything else.
+type Bind interface {
+	// ReceiveIPv4 reads a single IPv4 packet into buff, returning the number of bytes read
+	// and the Endpoint it came from.
+	ReceiveIPv4(buff []byte) (n int, ep Endpoint, err error)
+
+	// ReceiveIPv6 reads a single IPv6 packet into buff, returning the number of bytes read
+	// and the Endpoint it came from.
+	ReceiveIPv6(buff []byte) (n int, ep Endpoint, err error)
+
+	// Send transmits buff to the given Endpoint.
+	Send(buff []byte, end Endpoint) error
+
+	// SetMark sets the fwmark (SO_MARK) applied to packets sent through this Bind, used to
+	// keep OpenSPA traffic off of a client's own VPN tunnel.
+	SetMark(mark uint32) error
+
+	// PDUMaxSize returns the largest PDU this Bind can carry without fragmentation. UDP and
+	// TCP binds typically return defaultPDUMaxSize, while tunneled transports such as ICMP
+	// advertise a smaller size to leave room for their own encapsulation overhead.
+	PDUMaxSize() int
+
+	// Close releases any resources (sockets, goroutines) held by the Bind.
+	Close() error
+}
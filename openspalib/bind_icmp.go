@@ -0,0 +1,143 @@
+package openspalib
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"net"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+)
+
+// icmpPDUMaxSize leaves room for the 8-byte ICMP echo header on top of what UDP already
+// budgets for, so a PDU sized to the advertised maximum doesn't eat further into the MTU
+// margin than a plain UDP Bind would.
+const icmpPDUMaxSize = defaultPDUMaxSize - 8 // bytes
+
+// icmpEndpoint is the Endpoint implementation used by ICMPBind.
+type icmpEndpoint struct {
+	src net.IP
+	dst net.IP
+}
+
+func (e *icmpEndpoint) SrcIP() net.IP { return e.src }
+
+func (e *icmpEndpoint) DstIP() net.IP { return e.dst }
+
+func (e *icmpEndpoint) DstToBytes() []byte {
+	b, _ := ipAddressToBinIP(e.dst)
+	return b
+}
+
+func (e *icmpEndpoint) ClearSrc() { e.src = nil }
+
+// ICMPBind tunnels OpenSPA PDUs inside ICMP echo request/reply payloads. Many restrictive
+// networks permit outbound ping while blocking arbitrary UDP, which makes this a useful
+// fallback transport for clients behind such networks. The PDU is carried verbatim as the
+// ICMP echo payload; the identifier is randomized per Bind and the sequence number counts up
+// per packet, the way an ordinary ping's would, rather than following a fixed, fingerprintable
+// pattern.
+type ICMPBind struct {
+	conn *icmp.PacketConn
+	id   int
+	seq  int
+}
+
+// NewICMPBind opens a raw ICMP socket bound to laddr (use "" to bind all interfaces). The
+// echo identifier is randomized per Bind, the way a real ping process's PID-derived identifier
+// would vary run to run, rather than reusing a fixed value that would fingerprint every packet
+// this process ever sends as OpenSPA traffic.
+func NewICMPBind(laddr string) (*ICMPBind, error) {
+	conn, err := icmp.ListenPacket("ip4:icmp", laddr)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open icmp bind")
+	}
+
+	var idBuff [2]byte
+	if _, err := rand.Read(idBuff[:]); err != nil {
+		_ = conn.Close()
+		return nil, errors.Wrap(err, "failed to generate icmp echo identifier")
+	}
+
+	return &ICMPBind{conn: conn, id: int(binary.BigEndian.Uint16(idBuff[:]))}, nil
+}
+
+// receive reads from the raw ICMP socket until it sees an echo request/reply carrying this
+// Bind's identifier, skipping anything else. The socket sees every ICMP echo on the host - not
+// just this Bind's - since there's no way to filter by identifier at the kernel level the way
+// a connected UDP socket filters by port, so unrelated traffic (another process's ping, a
+// monitoring probe, a third party scanning the host) has to be filtered out here instead of
+// handed to the caller as if it were an OpenSPA PDU.
+func (b *ICMPBind) receive(buff []byte) (int, Endpoint, error) {
+	raw := make([]byte, len(buff)+128)
+
+	for {
+		n, raddr, err := b.conn.ReadFrom(raw)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		msg, err := icmp.ParseMessage(1 /* ipv4.ICMPTypeEchoReply.Protocol() */, raw[:n])
+		if err != nil {
+			continue // not a parseable ICMP message, definitely not ours
+		}
+
+		if msg.Type != ipv4.ICMPTypeEcho && msg.Type != ipv4.ICMPTypeEchoReply {
+			continue
+		}
+
+		echo, ok := msg.Body.(*icmp.Echo)
+		if !ok || echo.ID != b.id {
+			continue
+		}
+
+		ip, ok := raddr.(*net.IPAddr)
+		if !ok {
+			return 0, nil, ErrEndpointTypeMismatch
+		}
+
+		copied := copy(buff, echo.Data)
+		return copied, &icmpEndpoint{src: ip.IP, dst: ip.IP}, nil
+	}
+}
+
+func (b *ICMPBind) ReceiveIPv4(buff []byte) (int, Endpoint, error) { return b.receive(buff) }
+
+func (b *ICMPBind) ReceiveIPv6(buff []byte) (int, Endpoint, error) {
+	return 0, nil, errors.New("icmp bind currently only supports ipv4")
+}
+
+func (b *ICMPBind) Send(buff []byte, end Endpoint) error {
+	ep, ok := end.(*icmpEndpoint)
+	if !ok {
+		return ErrEndpointTypeMismatch
+	}
+
+	b.seq++
+	msg := icmp.Message{
+		Type: ipv4.ICMPTypeEcho,
+		Code: 0,
+		Body: &icmp.Echo{
+			ID:   b.id,
+			Seq:  b.seq,
+			Data: buff,
+		},
+	}
+
+	wire, err := msg.Marshal(nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.conn.WriteTo(wire, &net.IPAddr{IP: ep.dst})
+	return err
+}
+
+func (b *ICMPBind) SetMark(mark uint32) error {
+	return errors.New("icmp bind does not support setting a socket mark")
+}
+
+func (b *ICMPBind) PDUMaxSize() int { return icmpPDUMaxSize }
+
+func (b *ICMPBind) Close() error { return b.conn.Close() }
@@ -0,0 +1,219 @@
+//go:build linux
+
+package openspalib
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+
+	"github.com/mdlayher/socket"
+	"github.com/pkg/errors"
+	"golang.org/x/net/bpf"
+	"golang.org/x/sys/unix"
+)
+
+// packetEndpoint is the Endpoint implementation used by PacketBind.
+type packetEndpoint struct {
+	src net.IP
+	dst net.IP
+	// dstHW and ifIndex are carried so Send can address the reply to the exact client
+	// without a UDP socket (and therefore without a listening port) ever existing.
+	dstHW   net.HardwareAddr
+	ifIndex int
+}
+
+func (e *packetEndpoint) SrcIP() net.IP { return e.src }
+
+func (e *packetEndpoint) DstIP() net.IP { return e.dst }
+
+func (e *packetEndpoint) DstToBytes() []byte {
+	b, _ := ipAddressToBinIP(e.dst)
+	return b
+}
+
+func (e *packetEndpoint) ClearSrc() { e.src = nil }
+
+// PacketBind is a server-side, Linux-only Bind that reads OpenSPA PDUs directly off an
+// AF_PACKET raw socket instead of binding a UDP port. A BPF program filters the raw socket
+// down to well-formed OpenSPA v2 UDP datagrams before they ever reach user space, so the host
+// firewall can DROP all inbound UDP to the configured port and the daemon stays invisible to
+// a port scan - it only ever punches a firewall hole for a client after validating the
+// request, it never itself listens on anything an nmap -sU would see.
+type PacketBind struct {
+	conn *socket.Conn
+}
+
+// openspaBPFFilter builds a classic BPF program (run by the kernel against every packet seen
+// on the raw socket) that accepts only packets which could plausibly be an OpenSPA v2 request:
+// an IPv4/UDP datagram addressed to udpPort, whose first body byte has Version (2) in its
+// high nibble, and which is at least long enough to contain an OpenSPA header. This is a
+// coarse prefilter only - full validation (cipher suite, signature, etc.) still happens in
+// the higher layers; its job is to keep junk packets from ever waking up the daemon.
+func openspaBPFFilter(udpPort uint16) ([]bpf.RawInstruction, error) {
+	raw, err := bpf.Assemble([]bpf.Instruction{
+		// Load the IPv4 protocol field (offset 23 in the ethernet+ipv4 header).
+		bpf.LoadAbsolute{Off: 23, Size: 1},
+		bpf.JumpIf{Cond: bpf.JumpEqual, Val: uint32(unix.IPPROTO_UDP), SkipFalse: 8},
+
+		// Load the UDP destination port (offset 36, after a 14-byte ethernet header and a
+		// minimum 20-byte IPv4 header).
+		bpf.LoadAbsolute{Off: 36, Size: 2},
+		bpf.JumpIf{Cond: bpf.JumpEqual, Val: uint32(udpPort), SkipFalse: 6},
+
+		// Load the UDP length field (offset 38, which counts the 8-byte UDP header as well as
+		// the payload) and reject anything whose payload is shorter than a plausible OpenSPA
+		// header.
+		bpf.LoadAbsolute{Off: 38, Size: 2},
+		bpf.JumpIf{Cond: bpf.JumpLessThan, Val: uint32(udpHeaderSize + minHeaderSizeForFilter), SkipTrue: 4},
+
+		// Load the first byte of the UDP payload (offset 42) and check its high nibble matches
+		// the OpenSPA protocol version.
+		bpf.LoadAbsolute{Off: 42, Size: 1},
+		bpf.ALUOpConstant{Op: bpf.ALUOpShiftRight, Val: 4},
+		bpf.JumpIf{Cond: bpf.JumpEqual, Val: uint32(Version), SkipFalse: 1},
+
+		bpf.RetConstant{Val: 0x40000}, // accept (truncate to 256KiB, plenty for a PDU)
+		bpf.RetConstant{Val: 0},       // reject
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to assemble openspa bpf filter")
+	}
+
+	return raw, nil
+}
+
+// minHeaderSizeForFilter is a conservative lower bound on the OpenSPA header size used only
+// to reject obviously-too-short UDP payloads at the BPF layer; the real HeaderSize constant
+// is still authoritative for parsing.
+const minHeaderSizeForFilter = 16 // bytes
+
+// NewPacketBind opens an AF_PACKET raw socket on the named interface (empty ifaceName binds
+// all interfaces) and attaches a BPF program that prefilters for OpenSPA v2 traffic destined
+// for udpPort. No UDP socket is ever bound, so the kernel never answers a SYN/ping/portscan
+// probe against udpPort - it can safely be DROPped at the firewall.
+func NewPacketBind(ifaceName string, udpPort uint16) (*PacketBind, error) {
+	var ifIndex int
+	if ifaceName != "" {
+		iface, err := net.InterfaceByName(ifaceName)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to resolve interface for packet bind")
+		}
+		ifIndex = iface.Index
+	}
+
+	conn, err := socket.Socket(unix.AF_PACKET, unix.SOCK_RAW, int(htons(unix.ETH_P_IP)), "openspa", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open af_packet socket")
+	}
+
+	addr := &unix.SockaddrLinklayer{
+		Protocol: htons(unix.ETH_P_IP),
+		Ifindex:  ifIndex,
+	}
+	if err := conn.Bind(addr); err != nil {
+		_ = conn.Close()
+		return nil, errors.Wrap(err, "failed to bind af_packet socket")
+	}
+
+	filter, err := openspaBPFFilter(udpPort)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	if err := conn.SetBPF(filter); err != nil {
+		_ = conn.Close()
+		return nil, errors.Wrap(err, "failed to attach bpf filter to af_packet socket")
+	}
+
+	return &PacketBind{conn: conn}, nil
+}
+
+// htons converts a 16-bit value from host to network byte order.
+func htons(v uint16) uint16 {
+	b := make([]byte, 2)
+	binary.BigEndian.PutUint16(b, v)
+	return binary.LittleEndian.Uint16(b)
+}
+
+// ethHeaderSize and udpHeaderSize are the fixed-size headers PacketBind has to strip off of
+// a raw AF_PACKET frame before what's left is the OpenSPA payload every other Bind hands its
+// caller; the IPv4 header in between is variable-length (options) and is measured from the
+// frame itself via its IHL nibble.
+const (
+	ethHeaderSize = 14 // bytes
+	udpHeaderSize = 8  // bytes
+)
+
+func (b *PacketBind) receive(buff []byte) (int, Endpoint, error) {
+	// raw needs room for the ethernet/IPv4/UDP headers on top of the PDU itself, since those
+	// are stripped off below rather than returned to the caller.
+	raw := make([]byte, len(buff)+ethHeaderSize+60+udpHeaderSize)
+
+	n, raddr, err := b.conn.Recvfrom(context.Background(), raw, 0)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	ll, ok := raddr.(*unix.SockaddrLinklayer)
+	if !ok {
+		return 0, nil, ErrEndpointTypeMismatch
+	}
+
+	if n < ethHeaderSize+20+udpHeaderSize {
+		return 0, nil, errors.New("packet bind received frame too short to be an openspa pdu")
+	}
+
+	ipHeader := raw[ethHeaderSize:n]
+	ihl := int(ipHeader[0]&0x0F) * 4
+	if ihl < 20 || ethHeaderSize+ihl+udpHeaderSize > n {
+		return 0, nil, errors.New("packet bind received frame with invalid ipv4 header length")
+	}
+
+	srcIP := net.IPv4(ipHeader[12], ipHeader[13], ipHeader[14], ipHeader[15])
+
+	payloadOffset := ethHeaderSize + ihl + udpHeaderSize
+	payload := raw[payloadOffset:n]
+
+	copied := copy(buff, payload)
+
+	return copied, &packetEndpoint{
+		src:     srcIP,
+		dst:     srcIP,
+		dstHW:   ll.Addr[:6],
+		ifIndex: ll.Ifindex,
+	}, nil
+}
+
+func (b *PacketBind) ReceiveIPv4(buff []byte) (int, Endpoint, error) { return b.receive(buff) }
+
+func (b *PacketBind) ReceiveIPv6(buff []byte) (int, Endpoint, error) {
+	return 0, nil, errors.New("packet bind currently only supports ipv4")
+}
+
+func (b *PacketBind) Send(buff []byte, end Endpoint) error {
+	ep, ok := end.(*packetEndpoint)
+	if !ok {
+		return ErrEndpointTypeMismatch
+	}
+
+	addr := &unix.SockaddrLinklayer{
+		Protocol: htons(unix.ETH_P_IP),
+		Ifindex:  ep.ifIndex,
+		Halen:    6,
+	}
+	copy(addr.Addr[:6], ep.dstHW)
+
+	return b.conn.Sendto(context.Background(), buff, 0, addr)
+}
+
+func (b *PacketBind) SetMark(mark uint32) error {
+	return b.conn.SetsockoptInt(unix.SOL_SOCKET, unix.SO_MARK, int(mark))
+}
+
+// PDUMaxSize returns defaultPDUMaxSize since the raw socket carries full ethernet frames and
+// imposes no tighter limit of its own beyond the link MTU.
+func (b *PacketBind) PDUMaxSize() int { return defaultPDUMaxSize }
+
+func (b *PacketBind) Close() error { return b.conn.Close() }
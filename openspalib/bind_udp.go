@@ -0,0 +1,68 @@
+package openspalib
+
+import (
+	"net"
+
+	"github.com/pkg/errors"
+)
+
+// udpEndpoint is the Endpoint implementation used by UDPBind.
+type udpEndpoint struct {
+	src net.IP
+	dst *net.UDPAddr
+}
+
+func (e *udpEndpoint) SrcIP() net.IP { return e.src }
+
+func (e *udpEndpoint) DstIP() net.IP { return e.dst.IP }
+
+func (e *udpEndpoint) DstToBytes() []byte {
+	b, _ := ipAddressToBinIP(e.dst.IP)
+	return append(b, encodePort(uint16(e.dst.Port))...)
+}
+
+func (e *udpEndpoint) ClearSrc() { e.src = nil }
+
+// UDPBind is the default, plain UDP transport. It is the transport OpenSPA has historically
+// assumed, and remains the one used unless a stealthier or more restrictive-network-friendly
+// Bind is explicitly configured.
+type UDPBind struct {
+	conn *net.UDPConn
+}
+
+// NewUDPBind opens a UDP socket listening on laddr.
+func NewUDPBind(laddr *net.UDPAddr) (*UDPBind, error) {
+	conn, err := net.ListenUDP("udp", laddr)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open udp bind")
+	}
+
+	return &UDPBind{conn: conn}, nil
+}
+
+func (b *UDPBind) receive(buff []byte) (int, Endpoint, error) {
+	n, raddr, err := b.conn.ReadFromUDP(buff)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return n, &udpEndpoint{src: raddr.IP, dst: raddr}, nil
+}
+
+func (b *UDPBind) ReceiveIPv4(buff []byte) (int, Endpoint, error) { return b.receive(buff) }
+
+func (b *UDPBind) ReceiveIPv6(buff []byte) (int, Endpoint, error) { return b.receive(buff) }
+
+func (b *UDPBind) Send(buff []byte, end Endpoint) error {
+	ep, ok := end.(*udpEndpoint)
+	if !ok {
+		return ErrEndpointTypeMismatch
+	}
+
+	_, err := b.conn.WriteToUDP(buff, ep.dst)
+	return err
+}
+
+func (b *UDPBind) PDUMaxSize() int { return defaultPDUMaxSize }
+
+func (b *UDPBind) Close() error { return b.conn.Close() }
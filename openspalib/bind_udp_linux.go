@@ -0,0 +1,25 @@
+//go:build linux
+
+package openspalib
+
+import "golang.org/x/sys/unix"
+
+// SetMark sets the fwmark (SO_MARK) on the underlying UDP socket. SO_MARK is Linux-only, so
+// this is split out from bind_udp.go the same way bind_packet_linux.go is kept separate from
+// the portable Bind implementations.
+func (b *UDPBind) SetMark(mark uint32) error {
+	rawConn, err := b.conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var setErr error
+	err = rawConn.Control(func(fd uintptr) {
+		setErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_MARK, int(mark))
+	})
+	if err != nil {
+		return err
+	}
+
+	return setErr
+}
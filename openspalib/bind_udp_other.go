@@ -0,0 +1,11 @@
+//go:build !linux
+
+package openspalib
+
+import "github.com/pkg/errors"
+
+// SetMark always returns an error on platforms without SO_MARK (fwmark is a Linux-specific
+// socket option).
+func (b *UDPBind) SetMark(mark uint32) error {
+	return errors.New("udp bind does not support setting a socket mark on this platform")
+}
@@ -1,7 +1,6 @@
 package openspalib
 
 import (
-	"bytes"
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
@@ -14,7 +13,13 @@ import (
 
 const (
 	Version = 2 // version of the protocol
-	PDUMaxSize = 1408 // bytes (UDP payload i.e. OpenSPA header + body)
+
+	// PDUMaxSize is the default maximum PDU size, used when no Bind is available to ask
+	// (e.g. for allocating scratch buffers before a transport is known). Individual Binds
+	// may advertise a smaller value through Bind.PDUMaxSize(), e.g. an ICMP-tunneled Bind
+	// that needs to leave room for its own encapsulation overhead.
+	PDUMaxSize = defaultPDUMaxSize // bytes
+
 	BodyMaxSize = PDUMaxSize - HeaderSize // bytes
 )
 
@@ -91,42 +96,46 @@ func clientDeviceIdDecode(data []byte) (string, error) {
 	return clientDeviceId, nil
 }
 
-// Encodes a time.Time field into a unix 64-bit timestamp - 8 byte slice
+// timestampSize is the wire size, in bytes, of an encoded timestamp.
+const timestampSize = 8 // bytes
+
+// Encodes a time.Time field into a unix 64-bit timestamp - 8 byte slice. Allocates; prefer
+// timestampEncodeTo on hot paths such as request/response encode-decode.
 func timestampEncode(timestamp time.Time) []byte {
-	timestampBinBuffer := new(bytes.Buffer)
-	binary.Write(timestampBinBuffer, binary.BigEndian, timestamp.Unix())
+	buff := make([]byte, timestampSize)
+	timestampEncodeTo(buff, timestamp)
+	return buff
+}
 
-	timestampBin := timestampBinBuffer.Bytes()
-	return timestampBin
+// timestampEncodeTo writes the unix timestamp directly into buff, which must be at least
+// timestampSize bytes long. Avoids the bytes.Buffer/binary.Write indirection so the hot
+// request/response encode path doesn't allocate.
+func timestampEncodeTo(buff []byte, timestamp time.Time) {
+	binary.BigEndian.PutUint64(buff, uint64(timestamp.Unix()))
 }
 
 // Decodes an 8-byte timestamp byte slice into a time.Time field
 func timestampDecode(data []byte) (time.Time, error) {
-	const timestampSize = 8 // bytes
-
 	if len(data) != timestampSize {
 		return time.Time{}, ErrTimestampInvalid
 	}
 
-	var timestampInt int64
-
-	// decode the byte slice into an int64
-	timestampBuff := bytes.NewReader(data)
-	if err := binary.Read(timestampBuff, binary.BigEndian, &timestampInt); err != nil {
-		// Failed to decode timestamp
-		return time.Time{}, err
-	}
-
+	timestampInt := int64(binary.BigEndian.Uint64(data))
 	return time.Unix(timestampInt, 0), nil
 }
 
 // Encodes a port to a byte slice of size 2. Be careful to supply it a valid uin16 number.
 func encodePort(port uint16) []byte {
 	buff := make([]byte, 2)
-	binary.BigEndian.PutUint16(buff, port)
+	encodePortTo(buff, port)
 	return buff
 }
 
+// encodePortTo writes port directly into buff, which must be at least 2 bytes long.
+func encodePortTo(buff []byte, port uint16) {
+	binary.BigEndian.PutUint16(buff, port)
+}
+
 // Decodes a 2-byte port. Port 0 is disallowed and will trigger an error.
 func decodePort(data []byte, protocol InternetProtocolNumber) (uint16, error) {
 	port := binary.BigEndian.Uint16(data)
@@ -139,41 +148,47 @@ func decodePort(data []byte, protocol InternetProtocolNumber) (uint16, error) {
 	return port, nil
 }
 
+// miscFieldSize is the wire size, in bytes, of the encoded Misc field.
+const miscFieldSize = 4 // bytes
+
 // Encodes the parameters set in the Misc field. Always returns 4 byte long slice if no error is returned.
 func encodeMiscField(behindNAT bool, signatureOffset uint) ([]byte, error) {
-	// Byte 1: NXXXXXXX
-	// Byte 2: XXXXXXXX
-	// Byte 3: XXXXXXSS
-	// Byte 4: SSSSSSSS
-	//
-	// N - Client's behind NAT, boolean (1 bit)
-	// X - Reserved for future use (21 bits)
-	// S - Signature offset (10 bits)
-
-	var b1 byte = 0x0
-	var b2 byte = 0x0
-	var b3 byte = 0x0
-	var b4 byte = 0x0
+	buff := make([]byte, miscFieldSize)
+	if err := encodeMiscFieldTo(buff, behindNAT, signatureOffset); err != nil {
+		return nil, err
+	}
+	return buff, nil
+}
 
-	// Client is behind NAT - 1 bit
-	var clientBehindNat byte = 0x0 // BIN: 0000 0000 <- not behind nat
+// encodeMiscFieldTo writes the Misc field directly into buff, which must be at least
+// miscFieldSize bytes long.
+//
+// Byte 1: NXXXXXXX
+// Byte 2: XXXXXXXX
+// Byte 3: XXXXXXSS
+// Byte 4: SSSSSSSS
+//
+// N - Client's behind NAT, boolean (1 bit)
+// X - Reserved for future use (21 bits)
+// S - Signature offset (10 bits)
+func encodeMiscFieldTo(buff []byte, behindNAT bool, signatureOffset uint) error {
+	buff[0], buff[1], buff[2], buff[3] = 0x0, 0x0, 0x0, 0x0
 
+	// Client is behind NAT - 1 bit
 	if behindNAT {
-		clientBehindNat = 0x80 // BIN: 1000 0000 <- behind nat
+		buff[0] |= 0x80 // BIN: 1000 0000 <- behind nat
 	}
 
-	b1 = b1 | clientBehindNat
-
 	// Signature offset - 10 bits
 	if signatureOffset >= uint(math.Pow(2, signatureOffsetBitSize)) {
-		return nil, ErrSignatureOffsetTooLarge
+		return ErrSignatureOffsetTooLarge
 	}
 
 	sigOffset := uint16(signatureOffset)
-	b4 = uint8(sigOffset) & 0xFF
-	b3 = uint8(sigOffset >> 8) & 0x03
+	buff[3] = uint8(sigOffset) & 0xFF
+	buff[2] = uint8(sigOffset>>8) & 0x03
 
-	return []byte{b1, b2, b3, b4}, nil
+	return nil
 }
 
 // Returns from the misc field byte data the parsed values of:
@@ -271,12 +286,16 @@ func binIPAddressToIP(binIp []byte) (net.IP, error) {
 
 // Encodes the duration to a byte slice.
 func encodeDuration(dur time.Duration) []byte {
-	durSec := uint16(dur.Seconds())
 	buff := make([]byte, 2)
-	binary.BigEndian.PutUint16(buff, durSec)
+	encodeDurationTo(buff, dur)
 	return buff
 }
 
+// encodeDurationTo writes dur directly into buff, which must be at least 2 bytes long.
+func encodeDurationTo(buff []byte, dur time.Duration) {
+	binary.BigEndian.PutUint16(buff, uint16(dur.Seconds()))
+}
+
 // Decodes a 2-byte duration slice.
 func decodeDuration(data []byte) (time.Duration, error) {
 	duration := binary.BigEndian.Uint16(data)
@@ -0,0 +1,135 @@
+package openspalib
+
+import (
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// replayWindow is the maximum wall-clock skew, in either direction, tolerated between a
+// ReplayToken's embedded timestamp and the server's own clock before the token is rejected.
+// Such a token is reported with ErrReplayDetected, the same error as a seen or out-of-order
+// counter.
+const replayWindow = 30 * time.Second
+
+// replayTokenSize is the wire size, in bytes, of an encoded ReplayToken: 8 bytes of unix nano
+// timestamp and 8 bytes of monotonic per-device counter.
+const replayTokenSize = 8 + 8 // bytes
+
+var (
+	ErrReplayTokenInvalid = errors.New("replay token is invalid")
+	ErrReplayDetected     = errors.New("replay token has already been seen or is out of order")
+)
+
+// ReplayToken carries the nanosecond-granularity timestamp and per-device monotonic counter
+// used to detect replayed requests. It sits alongside the second-granularity timestamp field:
+// that field is still what's shown to the operator and used for request expiry, while
+// ReplayToken is what the ReplayCache checks a request against, since a counter at second
+// granularity is too coarse to catch a legitimate packet replayed within the same second and
+// is vulnerable to server clock skew on its own.
+type ReplayToken struct {
+	UnixNanos uint64
+	Counter   uint64
+}
+
+// encodeReplayToken encodes a ReplayToken into a replayTokenSize byte slice.
+func encodeReplayToken(token ReplayToken) []byte {
+	buff := make([]byte, replayTokenSize)
+	encodeReplayTokenTo(buff, token)
+	return buff
+}
+
+// encodeReplayTokenTo writes token directly into buff, which must be at least
+// replayTokenSize bytes long.
+func encodeReplayTokenTo(buff []byte, token ReplayToken) {
+	binary.BigEndian.PutUint64(buff[0:8], token.UnixNanos)
+	binary.BigEndian.PutUint64(buff[8:16], token.Counter)
+}
+
+// decodeReplayToken decodes a replayTokenSize byte slice into a ReplayToken.
+func decodeReplayToken(data []byte) (ReplayToken, error) {
+	if len(data) != replayTokenSize {
+		return ReplayToken{}, ErrReplayTokenInvalid
+	}
+
+	return ReplayToken{
+		UnixNanos: binary.BigEndian.Uint64(data[0:8]),
+		Counter:   binary.BigEndian.Uint64(data[8:16]),
+	}, nil
+}
+
+// ReplayCache tracks, per client device, the most recently accepted ReplayToken so that a
+// captured request can't be re-played against the server. Implementations are expected to be
+// safe for concurrent use. The in-memory implementation returned by NewMemoryReplayCache is
+// fine for a single gateway; operators running multiple SPA gateways in front of the same
+// protected service should back this interface with a shared store (e.g. Redis or BoltDB) so
+// that replay state is consistent across the fleet.
+type ReplayCache interface {
+	// CheckAndRecord validates token for deviceID against the last token seen for that device:
+	// the counter must strictly increase and the embedded timestamp must be within
+	// replayWindow of now. If valid, it records token as the new high-water mark for deviceID.
+	// Returns ErrReplayDetected if the token looks replayed or out of order, or if its timestamp
+	// is outside replayWindow.
+	CheckAndRecord(deviceID string, token ReplayToken, now time.Time) error
+}
+
+// pruneInterval bounds how often memoryReplayCache walks its whole map looking for stale
+// entries. A request rate under load shouldn't turn that walk into a per-request cost - that
+// would make the anti-replay check itself a DoS amplifier, exactly the scenario this series
+// exists to defend against - so the walk is time-gated rather than run on every call.
+const pruneInterval = replayWindow
+
+// memoryReplayCache is a process-local ReplayCache backed by a map, suitable for a single SPA
+// gateway instance. Stale entries are swept out at most once per pruneInterval, regardless of
+// how many requests arrive in between, so a flood of distinct device IDs can't turn eviction
+// into O(n) work per request.
+type memoryReplayCache struct {
+	mu         sync.Mutex
+	last       map[string]ReplayToken
+	lastPruned time.Time
+}
+
+// NewMemoryReplayCache returns a ReplayCache backed by an in-process map. It does not persist
+// across restarts and does not coordinate with other gateway instances; see ReplayCache for
+// when a shared backend is needed instead.
+func NewMemoryReplayCache() ReplayCache {
+	return &memoryReplayCache{last: make(map[string]ReplayToken)}
+}
+
+func (c *memoryReplayCache) CheckAndRecord(deviceID string, token ReplayToken, now time.Time) error {
+	tokenTime := time.Unix(0, int64(token.UnixNanos))
+	if tokenTime.Before(now.Add(-replayWindow)) || tokenTime.After(now.Add(replayWindow)) {
+		return ErrReplayDetected
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if prev, ok := c.last[deviceID]; ok && token.Counter <= prev.Counter {
+		return ErrReplayDetected
+	}
+
+	c.last[deviceID] = token
+
+	if now.Sub(c.lastPruned) >= pruneInterval {
+		c.pruneLocked(now)
+		c.lastPruned = now
+	}
+
+	return nil
+}
+
+// pruneLocked removes entries whose token timestamp has fallen out of replayWindow; once a
+// device's last-seen token is that stale, it can no longer affect a future CheckAndRecord call
+// (any new token for it will pass the timestamp check as a fresh entry), so it's safe to drop.
+// Must be called with c.mu held.
+func (c *memoryReplayCache) pruneLocked(now time.Time) {
+	cutoff := now.Add(-replayWindow)
+	for deviceID, token := range c.last {
+		if time.Unix(0, int64(token.UnixNanos)).Before(cutoff) {
+			delete(c.last, deviceID)
+		}
+	}
+}
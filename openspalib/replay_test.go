@@ -0,0 +1,102 @@
+package openspalib
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryReplayCacheAcceptsIncreasingCounter(t *testing.T) {
+	cache := NewMemoryReplayCache()
+	now := time.Unix(1_700_000_000, 0)
+
+	first := ReplayToken{UnixNanos: uint64(now.UnixNano()), Counter: 1}
+	if err := cache.CheckAndRecord("device-a", first, now); err != nil {
+		t.Fatalf("expected first token to be accepted, got error: %v", err)
+	}
+
+	second := ReplayToken{UnixNanos: uint64(now.UnixNano()), Counter: 2}
+	if err := cache.CheckAndRecord("device-a", second, now); err != nil {
+		t.Fatalf("expected token with higher counter to be accepted, got error: %v", err)
+	}
+}
+
+func TestMemoryReplayCacheRejectsNonIncreasingCounter(t *testing.T) {
+	cache := NewMemoryReplayCache()
+	now := time.Unix(1_700_000_000, 0)
+
+	token := ReplayToken{UnixNanos: uint64(now.UnixNano()), Counter: 5}
+	if err := cache.CheckAndRecord("device-a", token, now); err != nil {
+		t.Fatalf("expected first token to be accepted, got error: %v", err)
+	}
+
+	replayed := ReplayToken{UnixNanos: uint64(now.UnixNano()), Counter: 5}
+	if err := cache.CheckAndRecord("device-a", replayed, now); err != ErrReplayDetected {
+		t.Fatalf("expected replay of the same counter to be rejected, got: %v", err)
+	}
+
+	stale := ReplayToken{UnixNanos: uint64(now.UnixNano()), Counter: 4}
+	if err := cache.CheckAndRecord("device-a", stale, now); err != ErrReplayDetected {
+		t.Fatalf("expected a lower counter to be rejected, got: %v", err)
+	}
+}
+
+func TestMemoryReplayCacheTracksCountersPerDevice(t *testing.T) {
+	cache := NewMemoryReplayCache()
+	now := time.Unix(1_700_000_000, 0)
+
+	token := ReplayToken{UnixNanos: uint64(now.UnixNano()), Counter: 1}
+	if err := cache.CheckAndRecord("device-a", token, now); err != nil {
+		t.Fatalf("expected first token for device-a to be accepted, got error: %v", err)
+	}
+
+	// device-b has never been seen, so its counter starts fresh regardless of device-a's state.
+	if err := cache.CheckAndRecord("device-b", token, now); err != nil {
+		t.Fatalf("expected first token for device-b to be accepted, got error: %v", err)
+	}
+}
+
+func TestMemoryReplayCacheRejectsTokensOutsideTheWindow(t *testing.T) {
+	cache := NewMemoryReplayCache()
+	now := time.Unix(1_700_000_000, 0)
+
+	tooOld := ReplayToken{UnixNanos: uint64(now.Add(-replayWindow - time.Second).UnixNano()), Counter: 1}
+	if err := cache.CheckAndRecord("device-a", tooOld, now); err != ErrReplayDetected {
+		t.Fatalf("expected a token older than replayWindow to be rejected, got: %v", err)
+	}
+
+	tooNew := ReplayToken{UnixNanos: uint64(now.Add(replayWindow + time.Second).UnixNano()), Counter: 1}
+	if err := cache.CheckAndRecord("device-a", tooNew, now); err != ErrReplayDetected {
+		t.Fatalf("expected a token newer than replayWindow to be rejected, got: %v", err)
+	}
+
+	withinWindow := ReplayToken{UnixNanos: uint64(now.Add(replayWindow - time.Second).UnixNano()), Counter: 1}
+	if err := cache.CheckAndRecord("device-a", withinWindow, now); err != nil {
+		t.Fatalf("expected a token just inside replayWindow to be accepted, got error: %v", err)
+	}
+}
+
+func TestMemoryReplayCachePrunesStaleDevicesAfterPruneInterval(t *testing.T) {
+	cache := NewMemoryReplayCache().(*memoryReplayCache)
+	now := time.Unix(1_700_000_000, 0)
+
+	token := ReplayToken{UnixNanos: uint64(now.UnixNano()), Counter: 1}
+	if err := cache.CheckAndRecord("device-a", token, now); err != nil {
+		t.Fatalf("expected first token to be accepted, got error: %v", err)
+	}
+
+	// Advance time past both replayWindow and pruneInterval, then make an unrelated call -
+	// this is what triggers the time-gated sweep, not a background goroutine.
+	later := now.Add(pruneInterval + time.Second)
+	other := ReplayToken{UnixNanos: uint64(later.UnixNano()), Counter: 1}
+	if err := cache.CheckAndRecord("device-b", other, later); err != nil {
+		t.Fatalf("expected first token for device-b to be accepted, got error: %v", err)
+	}
+
+	cache.mu.Lock()
+	_, stillPresent := cache.last["device-a"]
+	cache.mu.Unlock()
+
+	if stillPresent {
+		t.Fatalf("expected device-a's stale entry to have been pruned")
+	}
+}
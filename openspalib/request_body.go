@@ -0,0 +1,92 @@
+package openspalib
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RequestBody is the OpenSPA request body: the misc field (NAT/signature-offset bits), the
+// replay token used for anti-replay detection, and zero or more access specs describing the
+// access being requested. Marshal/Unmarshal write and read it into/out of a caller-supplied
+// buffer. Marshal writes the misc field and replay token in place (encodeMiscFieldTo,
+// encodeReplayTokenTo); the access specs are encoded by encodeAccessSpecs into a separate
+// slice that is then copied into the buffer, so they are not written in place. Marshal bounds
+// the access spec list by BodyMaxSize instead of leaving that to the caller.
+type RequestBody struct {
+	BehindNAT       bool
+	SignatureOffset uint
+	ReplayToken     ReplayToken
+	AccessSpecs     []AccessSpec
+}
+
+// Marshal writes the request body directly into buf, which must be at least as large as the
+// encoded body. Returns the number of bytes written.
+func (b RequestBody) Marshal(buf []byte) (int, error) {
+	if len(b.AccessSpecs) > maxAccessSpecs {
+		return 0, ErrAccessSpecTooMany
+	}
+
+	size := miscFieldSize + replayTokenSize + len(b.AccessSpecs)*accessSpecSize
+	if size > BodyMaxSize {
+		return 0, ErrAccessSpecTooMany
+	}
+	if len(buf) < size {
+		return 0, errors.New("buffer too small for request body")
+	}
+
+	if err := encodeMiscFieldTo(buf[:miscFieldSize], b.BehindNAT, b.SignatureOffset); err != nil {
+		return 0, err
+	}
+
+	replayOffset := miscFieldSize
+	encodeReplayTokenTo(buf[replayOffset:replayOffset+replayTokenSize], b.ReplayToken)
+
+	specsOffset := replayOffset + replayTokenSize
+	specsBin, err := encodeAccessSpecs(b.AccessSpecs)
+	if err != nil {
+		return 0, err
+	}
+	copy(buf[specsOffset:size], specsBin)
+
+	return size, nil
+}
+
+// Unmarshal decodes a request body previously written by Marshal. Note that, as with
+// decodeMiscField itself, the signature offset bits aren't recovered - only the NAT bit is -
+// so SignatureOffset is always zero on a decoded RequestBody.
+func (b *RequestBody) Unmarshal(buf []byte) error {
+	if len(buf) < miscFieldSize+replayTokenSize {
+		return errors.New("request body too short to contain the misc field and replay token")
+	}
+
+	behindNAT, err := decodeMiscField(buf[0])
+	if err != nil {
+		return err
+	}
+
+	replayOffset := miscFieldSize
+	replayToken, err := decodeReplayToken(buf[replayOffset : replayOffset+replayTokenSize])
+	if err != nil {
+		return err
+	}
+
+	specsOffset := replayOffset + replayTokenSize
+	specs, err := decodeAccessSpecs(buf[specsOffset:])
+	if err != nil {
+		return err
+	}
+
+	b.BehindNAT = behindNAT
+	b.SignatureOffset = 0
+	b.ReplayToken = replayToken
+	b.AccessSpecs = specs
+	return nil
+}
+
+// CheckReplay validates the request body's ReplayToken for deviceID against cache, rejecting
+// the request if the token has already been seen or is out of order. Callers should invoke
+// this once per accepted request, after Unmarshal and before acting on the body's contents.
+func (b RequestBody) CheckReplay(deviceID string, cache ReplayCache, now time.Time) error {
+	return cache.CheckAndRecord(deviceID, b.ReplayToken, now)
+}
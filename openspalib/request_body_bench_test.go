@@ -0,0 +1,45 @@
+package openspalib
+
+import (
+	"net"
+	"testing"
+)
+
+// BenchmarkRequestBodyMarshalUnmarshal exercises a full request body encode-decode cycle,
+// including an access spec, using the RequestBody.Marshal/Unmarshal pair, whose misc field and
+// replay token go through the direct-buffer field encoders (encodeMiscFieldTo,
+// encodeReplayTokenTo) - so a future change to those encoders can be checked against this for
+// regressions.
+func BenchmarkRequestBodyMarshalUnmarshal(b *testing.B) {
+	body := RequestBody{
+		BehindNAT:       true,
+		SignatureOffset: 42,
+		ReplayToken:     ReplayToken{UnixNanos: 1234567890, Counter: 1},
+		AccessSpecs: []AccessSpec{
+			{
+				Protocol:     InternetProtocolNumber(6), // TCP
+				StartPort:    1024,
+				EndPort:      2048,
+				DstPrefix:    net.ParseIP("203.0.113.0"),
+				DstPrefixLen: 24,
+			},
+		},
+	}
+
+	buf := make([]byte, BodyMaxSize)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		n, err := body.Marshal(buf)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		var decoded RequestBody
+		if err := decoded.Unmarshal(buf[:n]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}